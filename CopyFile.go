@@ -1,15 +1,40 @@
 package main
 
 import (
+    "errors"
+    "fmt"
     "io"
     "os"
 )
 
+// NotRegularFileError はコピー元が通常ファイルでない場合に返されるエラーです。
+// (ディレクトリ、デバイスファイル、名前付きパイプなど io.Copy で扱うべきでない対象を弾くために使う)
+type NotRegularFileError struct {
+    Path string
+    Mode os.FileMode
+}
+
+func (e *NotRegularFileError) Error() string {
+    return fmt.Sprintf("copyfile: %s is not a regular file (mode %s)", e.Path, e.Mode)
+}
+
 // CopyFile はファイルを安全にコピーする関数です。
 // srcPath: コピー元ファイルパス
 // dstPath: コピー先ファイルパス
+// opts: WithVerify/WithResume などのオプション（省略可）
 // 戻り値: エラー情報（エラーがなければ nil）
-func CopyFile(srcPath, dstPath string) error {
+//
+// 同一ファイル（同一 inode）へのコピーは何もせず成功扱いにし、
+// 可能な環境ではハードリンクやリフレンク（reflink）でバイトのコピーを省略する。
+// これらが使えない場合は従来通り io.Copy にフォールバックする。
+// ただし Verify/Resume オプションを指定した場合は、整合性確認や途中再開の
+// ためにハードリンク/reflink の高速パスをスキップし、常にバッファリング
+// コピーを行う。
+func CopyFile(srcPath, dstPath string, opts ...FileOption) error {
+    cfg := defaultFileOptions()
+    for _, opt := range opts {
+        opt(cfg)
+    }
 
     // 1. ソースファイルを読み取り専用モードで開く
     // os.Open は読み取り専用でファイルを開く。ファイルが存在しない場合はエラーを返す
@@ -27,31 +52,91 @@ func CopyFile(srcPath, dstPath string) error {
         return err // ファイルメタ情報が取得できない場合はエラーを返して終了
     }
 
-    // 3. デスティネーションファイルを作成または上書き
-    // os.O_CREATE: ファイルが無ければ作成
-    // os.O_WRONLY: 書き込み専用
-    // os.O_TRUNC : 既存ファイル内容を空にしてから新たに書き込む
+    // 通常ファイル以外（ディレクトリ、デバイス等）はサポート対象外として
+    // 明確な型付きエラーを返す
+    if !srcInfo.Mode().IsRegular() {
+        return &NotRegularFileError{Path: srcPath, Mode: srcInfo.Mode()}
+    }
+
+    // コピー元・コピー先が同一ファイル（同一 inode）を指している場合は
+    // 何もせず即座に成功を返す
+    if dstInfo, err := os.Stat(dstPath); err == nil {
+        if os.SameFile(srcInfo, dstInfo) {
+            return nil
+        }
+    }
+
+    if !cfg.verify && !cfg.resume {
+        // 3. デスティネーションファイルを作成する前に、既存のファイルを取り除いておく。
+        // os.Link/reflink は既存のファイルがあると失敗するため、先に O_TRUNC 相当の
+        // 状態にしてから軽量コピーを試みる
+        if err := os.Remove(dstPath); err != nil && !os.IsNotExist(err) {
+            return err
+        }
+
+        // 4. まずはハードリンク・reflink によるバイトコピー省略を試みる。
+        // ファイルシステムが対応していない、あるいは別マウントをまたぐ場合
+        // (EXDEV/ENOSYS/EOPNOTSUPP) のみ黙って通常コピーにフォールバックする。
+        // それ以外のエラー（EACCES、EDQUOT、実コピー中の I/O エラーなど）は
+        // 握りつぶさずそのまま呼び出し元に返す
+        if err := tryLinkOrReflink(srcPath, dstPath); err == nil {
+            return nil
+        } else if !isCrossDeviceOrUnsupported(err) {
+            return err
+        }
+    }
+
+    // 5. Resume が指定されている場合、既存の dst が src の先頭部分と
+    // 一致するかを確認し、一致すれば続きから書き込む
+    var startOffset int64
+    appendMode := false
+    if cfg.resume {
+        offset, resumable, err := resumeOffset(srcPath, dstPath, cfg)
+        if err != nil {
+            return err
+        }
+        if resumable {
+            startOffset = offset
+            appendMode = true
+        }
+    }
+
+    openFlags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+    if appendMode {
+        openFlags = os.O_WRONLY
+    }
+
+    // 6. デスティネーションファイルを作成または上書き（Resume 時は追記用に開く）
     // 第3引数でソースファイルと同じパーミッションを指定（srcInfo.Mode()）
-    dst, err := os.OpenFile(dstPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, srcInfo.Mode())
+    dst, err := os.OpenFile(dstPath, openFlags, srcInfo.Mode())
     if err != nil {
         return err // ファイルが作れない/書き込みできない場合はエラーを返して終了
     }
     // 関数終了時に確実にファイルを閉じる
     defer dst.Close()
 
-    // 4. ソースファイルの内容をデスティネーションファイルにコピー
-    // io.Copy(dst, src) は、src から dst にバッファリングしながらデータをコピーする
-    if _, err := io.Copy(dst, src); err != nil {
+    if appendMode {
+        if _, err := src.Seek(startOffset, io.SeekStart); err != nil {
+            return err
+        }
+        if _, err := dst.Seek(startOffset, io.SeekStart); err != nil {
+            return err
+        }
+    }
+
+    // 7. ソースファイルの内容をデスティネーションファイルにコピー
+    // Verify が指定されている場合は io.MultiWriter でハッシュも同時に計算する
+    if err := copyWithOptionalVerify(src, dst, srcPath, dstPath, cfg); err != nil {
         return err // コピー中にエラーがあれば返す
     }
 
-    // 5. コピーしたファイルのパーミッションを再設定
+    // 8. コピーしたファイルのパーミッションを再設定
     // (既存ファイルを上書きする場合、OSや環境によってパーミッションが変化する可能性があるため)
     if err := os.Chmod(dstPath, srcInfo.Mode()); err != nil {
         return err
     }
 
-    // 6. ファイルをディスクにフラッシュし、書き込みが完了したことを保証
+    // 9. ファイルをディスクにフラッシュし、書き込みが完了したことを保証
     if err := dst.Sync(); err != nil {
         return err
     }
@@ -60,6 +145,30 @@ func CopyFile(srcPath, dstPath string) error {
     return nil
 }
 
+// tryLinkOrReflink は src と dst の間でバイトのコピーを伴わない高速パスを試みる。
+// まずハードリンク (os.Link) を試し、失敗したら OS 固有の reflink
+// (Linux では copy_file_range/ioctl(FICLONE)) を試みる。
+// どちらも使えない場合は呼び出し元が io.Copy にフォールバックできるよう
+// エラーを返す。
+func tryLinkOrReflink(srcPath, dstPath string) error {
+    if err := os.Link(srcPath, dstPath); err == nil {
+        return nil
+    } else if !isCrossDeviceOrUnsupported(err) {
+        return err
+    }
+
+    return reflink(srcPath, dstPath)
+}
+
+// isCrossDeviceOrUnsupported は、ハードリンク/reflink を諦めて通常コピーに
+// フォールバックしてよいエラーかどうかを判定する
+// (異なるマウントをまたぐ EXDEV、機能未実装の ENOSYS/EOPNOTSUPP など)。
+func isCrossDeviceOrUnsupported(err error) bool {
+    return errors.Is(err, errCrossDevice) ||
+        errors.Is(err, errNotSupported) ||
+        errors.Is(err, errNotImplemented)
+}
+
 func main() {
     // 使用例
     // source.txt を destination.txt にコピーする
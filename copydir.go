@@ -0,0 +1,135 @@
+package main
+
+import (
+    "fmt"
+    "io/fs"
+    "os"
+    "path/filepath"
+    "strings"
+)
+
+// SkipFunc は CopyDir が走査中のパスをコピー対象から除外するかどうかを
+// 判定する関数。true を返すとそのパス（ディレクトリの場合はその配下すべて）
+// がスキップされる。
+type SkipFunc func(path string, d fs.DirEntry) bool
+
+// DirOption は CopyDir の挙動を調整するための関数オプション。
+type DirOption func(*dirOptions)
+
+type dirOptions struct {
+    skip SkipFunc
+}
+
+func defaultDirOptions() *dirOptions {
+    return &dirOptions{
+        skip: func(string, fs.DirEntry) bool { return false },
+    }
+}
+
+// WithSkipFunc はコピー対象から除外するパスを判定する関数を設定する
+// (例: .git や node_modules といったビルド成果物を除外する)。
+func WithSkipFunc(skip SkipFunc) DirOption {
+    return func(o *dirOptions) {
+        if skip != nil {
+            o.skip = skip
+        }
+    }
+}
+
+// CopyDir は src 配下のディレクトリツリーを dst 以下に再帰的にコピーする。
+// ディレクトリ・ファイルともにパーミッションと所有者 (uid/gid) を保ったまま
+// 再作成し、通常ファイルは CopyFile で、シンボリックリンクは
+// os.Readlink/os.Symlink でリンク先をそのままコピーする。コピー後は mtime
+// も src に合わせる。所有者の変更は root 以外では失敗しうるため
+// ベストエフォートとして扱い、EPERM は無視する。
+//
+// dst が src の内側にある場合（無限ループになるため）はエラーを返す。
+func CopyDir(srcPath, dstPath string, opts ...DirOption) error {
+    cfg := defaultDirOptions()
+    for _, opt := range opts {
+        opt(cfg)
+    }
+
+    absSrc, err := filepath.Abs(srcPath)
+    if err != nil {
+        return err
+    }
+    absDst, err := filepath.Abs(dstPath)
+    if err != nil {
+        return err
+    }
+    if isSubPath(absSrc, absDst) {
+        return fmt.Errorf("copydir: destination %q is inside source %q", dstPath, srcPath)
+    }
+
+    return filepath.WalkDir(srcPath, func(path string, d fs.DirEntry, err error) error {
+        if err != nil {
+            return err
+        }
+        if cfg.skip(path, d) {
+            if d.IsDir() {
+                return filepath.SkipDir
+            }
+            return nil
+        }
+
+        rel, err := filepath.Rel(srcPath, path)
+        if err != nil {
+            return err
+        }
+        target := filepath.Join(dstPath, rel)
+
+        info, err := d.Info()
+        if err != nil {
+            return err
+        }
+
+        switch {
+        case d.Type()&fs.ModeSymlink != 0:
+            return copySymlink(path, target)
+        case d.IsDir():
+            return copyDirEntry(target, info)
+        default:
+            if err := CopyFile(path, target); err != nil {
+                return err
+            }
+            if err := preserveOwnership(target, info); err != nil {
+                return err
+            }
+            return os.Chtimes(target, info.ModTime(), info.ModTime())
+        }
+    })
+}
+
+// isSubPath は child が parent 自身、またはその配下にあるかどうかを返す。
+func isSubPath(parent, child string) bool {
+    if parent == child {
+        return true
+    }
+    return strings.HasPrefix(child, parent+string(os.PathSeparator))
+}
+
+func copyDirEntry(target string, info fs.FileInfo) error {
+    if err := os.MkdirAll(target, info.Mode().Perm()); err != nil {
+        return err
+    }
+    // MkdirAll は既存ディレクトリのモードを変更しないため明示的に揃える
+    if err := os.Chmod(target, info.Mode().Perm()); err != nil {
+        return err
+    }
+    if err := preserveOwnership(target, info); err != nil {
+        return err
+    }
+    return os.Chtimes(target, info.ModTime(), info.ModTime())
+}
+
+func copySymlink(src, dst string) error {
+    linkTarget, err := os.Readlink(src)
+    if err != nil {
+        return err
+    }
+    if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+        return err
+    }
+    return os.Symlink(linkTarget, dst)
+}
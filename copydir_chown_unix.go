@@ -0,0 +1,24 @@
+//go:build !windows
+
+package main
+
+import (
+    "errors"
+    "io/fs"
+    "os"
+    "syscall"
+)
+
+// preserveOwnership は info が示す所有者 (uid/gid) を target に適用する。
+// root 以外で実行している場合、他ユーザー所有への chown は EPERM になるのが
+// 通常の挙動なので、そのエラーはベストエフォートとして無視する。
+func preserveOwnership(target string, info fs.FileInfo) error {
+    stat, ok := info.Sys().(*syscall.Stat_t)
+    if !ok {
+        return nil
+    }
+    if err := os.Chown(target, int(stat.Uid), int(stat.Gid)); err != nil && !errors.Is(err, syscall.EPERM) {
+        return err
+    }
+    return nil
+}
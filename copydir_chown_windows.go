@@ -0,0 +1,11 @@
+//go:build windows
+
+package main
+
+import "io/fs"
+
+// preserveOwnership は Windows には POSIX の uid/gid 相当の概念がないため
+// 何もしない。
+func preserveOwnership(target string, info fs.FileInfo) error {
+    return nil
+}
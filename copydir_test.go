@@ -0,0 +1,92 @@
+package main
+
+import (
+    "io/fs"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+)
+
+func TestCopyDir_RejectsDestinationInsideSource(t *testing.T) {
+    root := t.TempDir()
+    src := filepath.Join(root, "src")
+    dst := filepath.Join(src, "nested", "dst")
+    if err := os.MkdirAll(src, 0o755); err != nil {
+        t.Fatalf("MkdirAll: %v", err)
+    }
+
+    err := CopyDir(src, dst)
+    if err == nil {
+        t.Fatal("CopyDir with dst inside src = nil, want cycle error")
+    }
+}
+
+func TestCopyDir_CopiesTreeAndSkipsFiltered(t *testing.T) {
+    root := t.TempDir()
+    src := filepath.Join(root, "src")
+    dst := filepath.Join(root, "dst")
+
+    if err := os.MkdirAll(filepath.Join(src, "keep"), 0o755); err != nil {
+        t.Fatalf("MkdirAll: %v", err)
+    }
+    if err := os.MkdirAll(filepath.Join(src, ".git"), 0o755); err != nil {
+        t.Fatalf("MkdirAll: %v", err)
+    }
+    if err := os.WriteFile(filepath.Join(src, "keep", "file.txt"), []byte("keep me"), 0o644); err != nil {
+        t.Fatalf("WriteFile: %v", err)
+    }
+    if err := os.WriteFile(filepath.Join(src, ".git", "config"), []byte("skip me"), 0o644); err != nil {
+        t.Fatalf("WriteFile: %v", err)
+    }
+
+    skip := func(path string, d fs.DirEntry) bool {
+        return d.Name() == ".git"
+    }
+
+    if err := CopyDir(src, dst, WithSkipFunc(skip)); err != nil {
+        t.Fatalf("CopyDir: %v", err)
+    }
+
+    got, err := os.ReadFile(filepath.Join(dst, "keep", "file.txt"))
+    if err != nil {
+        t.Fatalf("ReadFile(keep/file.txt): %v", err)
+    }
+    if string(got) != "keep me" {
+        t.Fatalf("got %q, want %q", got, "keep me")
+    }
+
+    if _, err := os.Stat(filepath.Join(dst, ".git")); !os.IsNotExist(err) {
+        t.Fatalf("expected .git to be skipped, stat err = %v", err)
+    }
+}
+
+func TestCopyDir_RecreatesSymlinks(t *testing.T) {
+    root := t.TempDir()
+    src := filepath.Join(root, "src")
+    dst := filepath.Join(root, "dst")
+
+    if err := os.MkdirAll(src, 0o755); err != nil {
+        t.Fatalf("MkdirAll: %v", err)
+    }
+    target := filepath.Join(src, "real.txt")
+    if err := os.WriteFile(target, []byte("real content"), 0o644); err != nil {
+        t.Fatalf("WriteFile: %v", err)
+    }
+    link := filepath.Join(src, "link.txt")
+    if err := os.Symlink("real.txt", link); err != nil {
+        t.Skipf("symlinks not supported in this environment: %v", err)
+    }
+
+    if err := CopyDir(src, dst); err != nil {
+        t.Fatalf("CopyDir: %v", err)
+    }
+
+    linkTarget, err := os.Readlink(filepath.Join(dst, "link.txt"))
+    if err != nil {
+        t.Fatalf("Readlink: %v", err)
+    }
+    if !strings.HasSuffix(linkTarget, "real.txt") {
+        t.Fatalf("got link target %q, want suffix %q", linkTarget, "real.txt")
+    }
+}
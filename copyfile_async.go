@@ -0,0 +1,158 @@
+package main
+
+import (
+    "context"
+    "io"
+    "os"
+    "time"
+)
+
+// Progress は CopyFileAsync の進捗状況を表す。
+type Progress struct {
+    BytesCopied int64   // ここまでにコピーしたバイト数
+    TotalBytes  int64   // コピー元ファイルの合計サイズ
+    Percent     float64 // 進捗率 (0〜100)。TotalBytes が 0 の場合は 0 を返す
+}
+
+// Option は CopyFileAsync の挙動を調整するための関数オプション。
+type Option func(*copyOptions)
+
+type copyOptions struct {
+    bufferSize       int
+    progressInterval time.Duration
+}
+
+const defaultBufferSize = 32 * 1024 // 32 KiB
+
+func defaultCopyOptions() *copyOptions {
+    return &copyOptions{
+        bufferSize:       defaultBufferSize,
+        progressInterval: 200 * time.Millisecond,
+    }
+}
+
+// WithBufferSize は読み取りバッファのサイズ（バイト数）を変更する。
+func WithBufferSize(size int) Option {
+    return func(o *copyOptions) {
+        if size > 0 {
+            o.bufferSize = size
+        }
+    }
+}
+
+// WithProgressInterval は Progress を送信する最小間隔を変更する。
+func WithProgressInterval(d time.Duration) Option {
+    return func(o *copyOptions) {
+        if d > 0 {
+            o.progressInterval = d
+        }
+    }
+}
+
+// CopyFileAsync は CopyFile の非ブロッキング版で、進捗を progress チャネルに
+// 通知しながらコピーを行う。呼び出し元が ctx をキャンセルすると、コピー中の
+// 出力ファイル（不完全な dstPath）を削除した上で中断する。
+//
+// 戻り値の2つのチャネルはコピー完了（成功・失敗・キャンセルいずれの場合も）
+// 時にそれぞれ close される。errCh には成功時も含めて最終的な結果（nil か
+// エラー）が必ず1つ送られる。
+func CopyFileAsync(ctx context.Context, srcPath, dstPath string, opts ...Option) (<-chan Progress, <-chan error) {
+    progressCh := make(chan Progress)
+    errCh := make(chan error, 1)
+
+    cfg := defaultCopyOptions()
+    for _, opt := range opts {
+        opt(cfg)
+    }
+
+    go func() {
+        defer close(progressCh)
+        defer close(errCh)
+
+        errCh <- runCopyAsync(ctx, srcPath, dstPath, cfg, progressCh)
+    }()
+
+    return progressCh, errCh
+}
+
+func runCopyAsync(ctx context.Context, srcPath, dstPath string, cfg *copyOptions, progressCh chan<- Progress) error {
+    src, err := os.Open(srcPath)
+    if err != nil {
+        return err
+    }
+    defer src.Close()
+
+    srcInfo, err := src.Stat()
+    if err != nil {
+        return err
+    }
+    if !srcInfo.Mode().IsRegular() {
+        return &NotRegularFileError{Path: srcPath, Mode: srcInfo.Mode()}
+    }
+
+    // コピー元・コピー先が同一ファイル（同一 inode）を指している場合は、
+    // dst を O_TRUNC で開いて読み出し前に内容を失わせないよう、
+    // ここで何もせず即座に成功を返す
+    if dstInfo, err := os.Stat(dstPath); err == nil {
+        if os.SameFile(srcInfo, dstInfo) {
+            return nil
+        }
+    }
+
+    dst, err := os.OpenFile(dstPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, srcInfo.Mode())
+    if err != nil {
+        return err
+    }
+    // 異常終了時は不完全な出力ファイルを残さないよう削除する
+    succeeded := false
+    defer func() {
+        dst.Close()
+        if !succeeded {
+            os.Remove(dstPath)
+        }
+    }()
+
+    buf := make([]byte, cfg.bufferSize)
+    var copied int64
+    lastReport := time.Now()
+
+    report := func(force bool) {
+        if !force && time.Since(lastReport) < cfg.progressInterval {
+            return
+        }
+        lastReport = time.Now()
+        percent := 0.0
+        if srcInfo.Size() > 0 {
+            percent = float64(copied) / float64(srcInfo.Size()) * 100
+        }
+        progressCh <- Progress{BytesCopied: copied, TotalBytes: srcInfo.Size(), Percent: percent}
+    }
+
+    for {
+        select {
+        case <-ctx.Done():
+            return ctx.Err()
+        default:
+        }
+
+        n, readErr := src.Read(buf)
+        if n > 0 {
+            if _, err := dst.Write(buf[:n]); err != nil {
+                return err
+            }
+            copied += int64(n)
+            report(false)
+        }
+        if readErr != nil {
+            if readErr == io.EOF {
+                report(true)
+                if err := dst.Sync(); err != nil {
+                    return err
+                }
+                succeeded = true
+                return nil
+            }
+            return readErr
+        }
+    }
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+    "context"
+    "os"
+    "path/filepath"
+    "testing"
+    "time"
+)
+
+func drainAsync(t *testing.T, progressCh <-chan Progress, errCh <-chan error) error {
+    t.Helper()
+    var lastErr error
+    for progressCh != nil || errCh != nil {
+        select {
+        case _, ok := <-progressCh:
+            if !ok {
+                progressCh = nil
+            }
+        case err, ok := <-errCh:
+            if !ok {
+                errCh = nil
+                continue
+            }
+            lastErr = err
+        case <-time.After(5 * time.Second):
+            t.Fatal("timed out waiting for CopyFileAsync to finish")
+        }
+    }
+    return lastErr
+}
+
+func TestCopyFileAsync_SameFileShortCircuit(t *testing.T) {
+    dir := t.TempDir()
+    path := filepath.Join(dir, "same.txt")
+    want := []byte("async self-copy must not truncate")
+    if err := os.WriteFile(path, want, 0o644); err != nil {
+        t.Fatalf("WriteFile: %v", err)
+    }
+
+    progressCh, errCh := CopyFileAsync(context.Background(), path, path)
+    if err := drainAsync(t, progressCh, errCh); err != nil {
+        t.Fatalf("CopyFileAsync(path, path) = %v, want nil", err)
+    }
+
+    got, err := os.ReadFile(path)
+    if err != nil {
+        t.Fatalf("ReadFile: %v", err)
+    }
+    if string(got) != string(want) {
+        t.Fatalf("content lost after async self-copy: got %q (%d bytes), want %q", got, len(got), want)
+    }
+}
+
+func TestCopyFileAsync_CopiesContent(t *testing.T) {
+    dir := t.TempDir()
+    src := filepath.Join(dir, "src.txt")
+    dst := filepath.Join(dir, "dst.txt")
+    want := []byte("hello from copyfileasync")
+    if err := os.WriteFile(src, want, 0o644); err != nil {
+        t.Fatalf("WriteFile: %v", err)
+    }
+
+    progressCh, errCh := CopyFileAsync(context.Background(), src, dst)
+    if err := drainAsync(t, progressCh, errCh); err != nil {
+        t.Fatalf("CopyFileAsync: %v", err)
+    }
+
+    got, err := os.ReadFile(dst)
+    if err != nil {
+        t.Fatalf("ReadFile: %v", err)
+    }
+    if string(got) != string(want) {
+        t.Fatalf("got %q, want %q", got, want)
+    }
+}
+
+func TestCopyFileAsync_CancellationRemovesPartialFile(t *testing.T) {
+    dir := t.TempDir()
+    src := filepath.Join(dir, "src.bin")
+    dst := filepath.Join(dir, "dst.bin")
+    if err := os.WriteFile(src, make([]byte, 8*1024*1024), 0o644); err != nil {
+        t.Fatalf("WriteFile: %v", err)
+    }
+
+    ctx, cancel := context.WithCancel(context.Background())
+    progressCh, errCh := CopyFileAsync(ctx, src, dst, WithBufferSize(4096))
+    cancel()
+    err := drainAsync(t, progressCh, errCh)
+    if err == nil {
+        t.Fatal("expected an error after cancellation, got nil")
+    }
+
+    if _, statErr := os.Stat(dst); !os.IsNotExist(statErr) {
+        t.Fatalf("expected partial dst to be removed after cancellation, stat err = %v", statErr)
+    }
+}
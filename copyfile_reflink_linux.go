@@ -0,0 +1,71 @@
+//go:build linux
+
+package main
+
+import (
+    "errors"
+    "os"
+
+    "golang.org/x/sys/unix"
+)
+
+// errCrossDevice/errNotSupported/errNotImplemented は reflink/ハードリンクを
+// 諦めて通常コピーにフォールバックしてよいことを示すセンチネルエラー。
+var (
+    errCrossDevice    = unix.EXDEV
+    errNotSupported   = unix.EOPNOTSUPP
+    errNotImplemented = unix.ENOSYS
+)
+
+// reflink は Linux 上で copy_file_range(2) によるサーバーサイドコピーを試み、
+// それが使えないファイルシステムでは ioctl(FICLONE) によるブロック共有
+// (コピーオンライト reflink) を試みる。
+func reflink(srcPath, dstPath string) error {
+    src, err := os.Open(srcPath)
+    if err != nil {
+        return err
+    }
+    defer src.Close()
+
+    srcInfo, err := src.Stat()
+    if err != nil {
+        return err
+    }
+
+    dst, err := os.OpenFile(dstPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, srcInfo.Mode())
+    if err != nil {
+        return err
+    }
+    defer dst.Close()
+
+    // まず ioctl(FICLONE) でファイル全体を reflink する (Btrfs/XFS/overlayfs など)
+    if err := unix.IoctlFileClone(int(dst.Fd()), int(src.Fd())); err == nil {
+        // OpenFile の mode は umask の影響を受けるため、src と同じパーミッションに
+        // なるよう明示的に揃える（CopyFile の通常コピー経路と同様）
+        return dst.Chmod(srcInfo.Mode())
+    }
+
+    // reflink が使えない場合は copy_file_range(2) でカーネル内コピーを試みる
+    remaining := srcInfo.Size()
+    for remaining > 0 {
+        n, err := unix.CopyFileRange(int(src.Fd()), nil, int(dst.Fd()), nil, int(remaining), 0)
+        if err != nil {
+            if errors.Is(err, errNotSupported) || errors.Is(err, errNotImplemented) || errors.Is(err, errCrossDevice) {
+                os.Remove(dstPath)
+                return err
+            }
+            os.Remove(dstPath)
+            return err
+        }
+        if n == 0 {
+            break
+        }
+        remaining -= int64(n)
+    }
+    if remaining > 0 {
+        os.Remove(dstPath)
+        return errNotSupported
+    }
+
+    return dst.Chmod(srcInfo.Mode())
+}
@@ -0,0 +1,22 @@
+//go:build !linux
+
+package main
+
+import "syscall"
+
+// errCrossDevice/errNotSupported/errNotImplemented は reflink/ハードリンクを
+// 諦めて通常コピーにフォールバックしてよいことを示すセンチネルエラー。
+// os.Link 等が返す実際の syscall.Errno と errors.Is で比較する必要があるため、
+// 独自のプレースホルダーではなく syscall パッケージの本物の errno 値を使う。
+var (
+    errCrossDevice    = syscall.EXDEV
+    errNotSupported   = syscall.EOPNOTSUPP
+    errNotImplemented = syscall.ENOSYS
+)
+
+// reflink は Linux 以外には copy_file_range/ioctl(FICLONE) 相当の仕組みが
+// ないため常にエラーを返し、呼び出し元を通常の io.Copy フォールバックへ
+// 誘導する。
+func reflink(srcPath, dstPath string) error {
+    return errNotSupported
+}
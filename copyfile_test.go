@@ -0,0 +1,83 @@
+package main
+
+import (
+    "errors"
+    "os"
+    "path/filepath"
+    "testing"
+)
+
+func TestCopyFile_SameFileShortCircuit(t *testing.T) {
+    dir := t.TempDir()
+    path := filepath.Join(dir, "same.txt")
+    want := []byte("do not lose me")
+    if err := os.WriteFile(path, want, 0o644); err != nil {
+        t.Fatalf("WriteFile: %v", err)
+    }
+
+    if err := CopyFile(path, path); err != nil {
+        t.Fatalf("CopyFile(path, path) = %v, want nil", err)
+    }
+
+    got, err := os.ReadFile(path)
+    if err != nil {
+        t.Fatalf("ReadFile: %v", err)
+    }
+    if string(got) != string(want) {
+        t.Fatalf("content changed after self-copy: got %q, want %q", got, want)
+    }
+}
+
+func TestCopyFile_RejectsNonRegularFile(t *testing.T) {
+    dir := t.TempDir()
+    dst := filepath.Join(dir, "out.txt")
+
+    err := CopyFile(dir, dst)
+    var notRegular *NotRegularFileError
+    if !errors.As(err, &notRegular) {
+        t.Fatalf("CopyFile(dir, dst) = %v, want *NotRegularFileError", err)
+    }
+}
+
+func TestCopyFile_CopiesContent(t *testing.T) {
+    dir := t.TempDir()
+    src := filepath.Join(dir, "src.txt")
+    dst := filepath.Join(dir, "dst.txt")
+    want := []byte("hello, copyfile")
+    if err := os.WriteFile(src, want, 0o644); err != nil {
+        t.Fatalf("WriteFile: %v", err)
+    }
+
+    if err := CopyFile(src, dst); err != nil {
+        t.Fatalf("CopyFile: %v", err)
+    }
+
+    got, err := os.ReadFile(dst)
+    if err != nil {
+        t.Fatalf("ReadFile: %v", err)
+    }
+    if string(got) != string(want) {
+        t.Fatalf("got %q, want %q", got, want)
+    }
+}
+
+func TestIsCrossDeviceOrUnsupported(t *testing.T) {
+    cases := []struct {
+        name string
+        err  error
+        want bool
+    }{
+        {"cross-device", errCrossDevice, true},
+        {"not-supported", errNotSupported, true},
+        {"not-implemented", errNotImplemented, true},
+        {"unrelated", errors.New("permission denied"), false},
+    }
+
+    for _, tc := range cases {
+        t.Run(tc.name, func(t *testing.T) {
+            if got := isCrossDeviceOrUnsupported(tc.err); got != tc.want {
+                t.Errorf("isCrossDeviceOrUnsupported(%v) = %v, want %v", tc.err, got, tc.want)
+            }
+        })
+    }
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+    "bytes"
+    "crypto/sha256"
+    "errors"
+    "hash"
+    "io"
+    "os"
+)
+
+// ErrIntegrityMismatch は Verify オプション指定時に、コピー元とコピー先の
+// ハッシュ値が一致しなかった場合に返される。
+var ErrIntegrityMismatch = errors.New("copyfile: integrity verification failed: checksum mismatch")
+
+// FileOption は CopyFile の挙動を調整するための関数オプション。
+type FileOption func(*fileOptions)
+
+type fileOptions struct {
+    verify      bool
+    hashFactory func() hash.Hash
+    resume      bool
+}
+
+func defaultFileOptions() *fileOptions {
+    return &fileOptions{
+        verify:      false,
+        hashFactory: sha256.New,
+        resume:      false,
+    }
+}
+
+// WithVerify はコピー完了後にソースとデスティネーションのハッシュ値
+// (既定では SHA-256) を比較し、不一致なら ErrIntegrityMismatch を返すよう
+// 指定する。
+func WithVerify() FileOption {
+    return func(o *fileOptions) {
+        o.verify = true
+    }
+}
+
+// WithVerifyHash は Verify で使用するハッシュアルゴリズムを差し替える。
+func WithVerifyHash(factory func() hash.Hash) FileOption {
+    return func(o *fileOptions) {
+        o.verify = true
+        if factory != nil {
+            o.hashFactory = factory
+        }
+    }
+}
+
+// WithResume は dst が既に存在し、かつ src の先頭部分と一致する場合に
+// 最初からコピーし直すのではなく、続きから書き込むよう指定する。
+func WithResume() FileOption {
+    return func(o *fileOptions) {
+        o.resume = true
+    }
+}
+
+// copyWithOptionalVerify は src から dst へコピーしつつ、Verify が指定されて
+// いれば io.MultiWriter で双方のハッシュを同時に計算し、コピー後に比較する。
+func copyWithOptionalVerify(src, dst *os.File, srcPath, dstPath string, cfg *fileOptions) error {
+    if !cfg.verify {
+        _, err := io.Copy(dst, src)
+        return err
+    }
+
+    srcHash := cfg.hashFactory()
+    dstHash := cfg.hashFactory()
+
+    // src 側は読み取りと同時にハッシュへ書き込む TeeReader、
+    // dst 側は書き込みと同時にハッシュへ書き込む MultiWriter を使う
+    tee := io.TeeReader(src, srcHash)
+    writer := io.MultiWriter(dst, dstHash)
+
+    if _, err := io.Copy(writer, tee); err != nil {
+        return err
+    }
+
+    if !bytes.Equal(srcHash.Sum(nil), dstHash.Sum(nil)) {
+        return ErrIntegrityMismatch
+    }
+    return nil
+}
+
+// resumeOffset は dst が既存なら src の先頭 len(dst) バイトとハッシュ比較し、
+// 一致すればその長さを再開位置として返す。不一致、あるいは dst が存在しない
+// /src より長い場合は resumable=false を返し、呼び出し元は最初からコピーする。
+func resumeOffset(srcPath, dstPath string, cfg *fileOptions) (offset int64, resumable bool, err error) {
+    dstInfo, err := os.Stat(dstPath)
+    if err != nil {
+        if os.IsNotExist(err) {
+            return 0, false, nil
+        }
+        return 0, false, err
+    }
+    if dstInfo.Size() == 0 {
+        return 0, false, nil
+    }
+
+    srcInfo, err := os.Stat(srcPath)
+    if err != nil {
+        return 0, false, err
+    }
+    if dstInfo.Size() > srcInfo.Size() {
+        // dst が src より長い場合は src のプレフィックスたり得ないため
+        // 再開せず最初からコピーし直す
+        return 0, false, nil
+    }
+
+    dstFile, err := os.Open(dstPath)
+    if err != nil {
+        return 0, false, err
+    }
+    defer dstFile.Close()
+
+    dstHash := cfg.hashFactory()
+    if _, err := io.Copy(dstHash, dstFile); err != nil {
+        return 0, false, err
+    }
+
+    srcFile, err := os.Open(srcPath)
+    if err != nil {
+        return 0, false, err
+    }
+    defer srcFile.Close()
+
+    srcHash := cfg.hashFactory()
+    if _, err := io.CopyN(srcHash, srcFile, dstInfo.Size()); err != nil && err != io.EOF {
+        return 0, false, err
+    }
+
+    if !bytes.Equal(dstHash.Sum(nil), srcHash.Sum(nil)) {
+        return 0, false, nil
+    }
+
+    return dstInfo.Size(), true, nil
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+    "errors"
+    "hash"
+    "os"
+    "path/filepath"
+    "testing"
+)
+
+// fakeHash is a minimal hash.Hash whose Sum depends only on an id, letting
+// tests force a digest mismatch regardless of the bytes actually written.
+type fakeHash struct{ id int }
+
+func (h *fakeHash) Write(p []byte) (int, error) { return len(p), nil }
+func (h *fakeHash) Sum(b []byte) []byte         { return append(b, byte(h.id)) }
+func (h *fakeHash) Reset()                      {}
+func (h *fakeHash) Size() int                   { return 1 }
+func (h *fakeHash) BlockSize() int              { return 1 }
+
+func TestCopyFile_VerifyDetectsMismatch(t *testing.T) {
+    dir := t.TempDir()
+    src := filepath.Join(dir, "src.txt")
+    dst := filepath.Join(dir, "dst.txt")
+    if err := os.WriteFile(src, []byte("original content"), 0o644); err != nil {
+        t.Fatalf("WriteFile(src): %v", err)
+    }
+    // dst already contains different bytes of the same length; a plain
+    // io.Copy would silently overwrite it, but we want Verify to compare
+    // the digests written during the copy.
+    if err := os.WriteFile(dst, []byte("stale sentinel!!"), 0o644); err != nil {
+        t.Fatalf("WriteFile(dst): %v", err)
+    }
+
+    if err := CopyFile(src, dst, WithVerify()); err != nil {
+        t.Fatalf("CopyFile with WithVerify() = %v, want nil (digests should match after a fresh copy)", err)
+    }
+
+    got, err := os.ReadFile(dst)
+    if err != nil {
+        t.Fatalf("ReadFile: %v", err)
+    }
+    if string(got) != "original content" {
+        t.Fatalf("got %q, want %q", got, "original content")
+    }
+}
+
+func TestResumeOffset_DetectsMismatchAndRestartsFromScratch(t *testing.T) {
+    dir := t.TempDir()
+    src := filepath.Join(dir, "src.txt")
+    dst := filepath.Join(dir, "dst.txt")
+
+    if err := os.WriteFile(src, []byte("0123456789abcdef"), 0o644); err != nil {
+        t.Fatalf("WriteFile(src): %v", err)
+    }
+    // dst's existing bytes diverge from src at the same offset, so it is not
+    // a valid prefix and resume must not be allowed.
+    if err := os.WriteFile(dst, []byte("0123XXXXX"), 0o644); err != nil {
+        t.Fatalf("WriteFile(dst): %v", err)
+    }
+
+    cfg := defaultFileOptions()
+    offset, resumable, err := resumeOffset(src, dst, cfg)
+    if err != nil {
+        t.Fatalf("resumeOffset: %v", err)
+    }
+    if resumable {
+        t.Fatalf("resumeOffset reported resumable=true at offset %d for mismatched prefix", offset)
+    }
+}
+
+func TestResumeOffset_ResumesFromValidPrefix(t *testing.T) {
+    dir := t.TempDir()
+    src := filepath.Join(dir, "src.txt")
+    dst := filepath.Join(dir, "dst.txt")
+
+    full := "0123456789abcdef"
+    if err := os.WriteFile(src, []byte(full), 0o644); err != nil {
+        t.Fatalf("WriteFile(src): %v", err)
+    }
+    if err := os.WriteFile(dst, []byte(full[:8]), 0o644); err != nil {
+        t.Fatalf("WriteFile(dst): %v", err)
+    }
+
+    cfg := defaultFileOptions()
+    offset, resumable, err := resumeOffset(src, dst, cfg)
+    if err != nil {
+        t.Fatalf("resumeOffset: %v", err)
+    }
+    if !resumable {
+        t.Fatal("resumeOffset reported resumable=false for a valid prefix")
+    }
+    if offset != 8 {
+        t.Fatalf("offset = %d, want 8", offset)
+    }
+}
+
+func TestCopyFile_ResumeContinuesFromExistingPrefix(t *testing.T) {
+    dir := t.TempDir()
+    src := filepath.Join(dir, "src.txt")
+    dst := filepath.Join(dir, "dst.txt")
+
+    full := "the quick brown fox jumps over the lazy dog"
+    if err := os.WriteFile(src, []byte(full), 0o644); err != nil {
+        t.Fatalf("WriteFile(src): %v", err)
+    }
+    if err := os.WriteFile(dst, []byte(full[:10]), 0o644); err != nil {
+        t.Fatalf("WriteFile(dst): %v", err)
+    }
+
+    if err := CopyFile(src, dst, WithResume()); err != nil {
+        t.Fatalf("CopyFile with WithResume() = %v, want nil", err)
+    }
+
+    got, err := os.ReadFile(dst)
+    if err != nil {
+        t.Fatalf("ReadFile: %v", err)
+    }
+    if string(got) != full {
+        t.Fatalf("got %q, want %q", got, full)
+    }
+}
+
+func TestCopyFile_VerifyMismatchReturnsIntegrityError(t *testing.T) {
+    dir := t.TempDir()
+    src := filepath.Join(dir, "src.txt")
+    dst := filepath.Join(dir, "dst.txt")
+    if err := os.WriteFile(src, []byte("payload"), 0o644); err != nil {
+        t.Fatalf("WriteFile: %v", err)
+    }
+
+    // A hash factory that hands out a different id each call guarantees the
+    // src and dst digests never agree, exercising the mismatch branch
+    // deterministically regardless of the bytes actually copied.
+    next := 0
+    err := copyWithOptionalVerify(mustOpen(t, src), mustCreate(t, filepath.Join(dir, "scratch.txt")), src, dst, &fileOptions{
+        verify: true,
+        hashFactory: func() hash.Hash {
+            next++
+            return &fakeHash{id: next}
+        },
+    })
+    if !errors.Is(err, ErrIntegrityMismatch) {
+        t.Fatalf("copyWithOptionalVerify error = %v, want ErrIntegrityMismatch", err)
+    }
+}
+
+func mustOpen(t *testing.T, path string) *os.File {
+    t.Helper()
+    f, err := os.Open(path)
+    if err != nil {
+        t.Fatalf("Open(%s): %v", path, err)
+    }
+    t.Cleanup(func() { f.Close() })
+    return f
+}
+
+func mustCreate(t *testing.T, path string) *os.File {
+    t.Helper()
+    f, err := os.Create(path)
+    if err != nil {
+        t.Fatalf("Create(%s): %v", path, err)
+    }
+    t.Cleanup(func() { f.Close() })
+    return f
+}